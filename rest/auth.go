@@ -0,0 +1,36 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+)
+
+type claimsContextKey struct{}
+
+// withClaims attaches the Claims an AuthBackend produced for r to its context.
+func withClaims(r *http.Request, claims Claims) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), claimsContextKey{}, claims))
+}
+
+// ClaimsFromContext returns the Claims an AuthBackend populated on r's
+// context, if any.
+func ClaimsFromContext(r *http.Request) (Claims, bool) {
+	claims, ok := r.Context().Value(claimsContextKey{}).(Claims)
+	return claims, ok
+}
+
+type (
+	// Claims holds the identity information extracted from a request by an
+	// AuthBackend, e.g. JWT claims or the result of a token introspection
+	// call. It's handed to downstream handlers through the request context
+	// the same way jwt claims are today.
+	Claims map[string]interface{}
+
+	// AuthBackend authenticates an incoming request and returns the Claims
+	// carried by it. Authenticate returning a non-nil error triggers the
+	// configured UnauthorizedCallback, the same way a jwt/signature failure
+	// does today.
+	AuthBackend interface {
+		Authenticate(r *http.Request) (Claims, error)
+	}
+)
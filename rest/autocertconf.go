@@ -0,0 +1,42 @@
+package rest
+
+import "golang.org/x/crypto/acme/autocert"
+
+// An AutocertConf is the config for serving TLS certificates issued
+// automatically by an ACME CA such as Let's Encrypt.
+type AutocertConf struct {
+	// Hosts restricts certificate issuance to the given host names.
+	// It's required, autocert refuses to issue a certificate for an
+	// unlisted host.
+	Hosts []string
+	// CacheDir is where issued certificates are cached on disk. Ignored
+	// if Cache is set. Defaults to "autocert" in the working directory.
+	CacheDir string `json:",optional"`
+	// Cache is a pluggable autocert.Cache, e.g. backed by redis or etcd,
+	// letting certificates be shared across horizontally-scaled instances.
+	// Takes precedence over CacheDir.
+	Cache autocert.Cache `json:"-"`
+	// Email is the contact address used when registering the ACME account.
+	Email string `json:",optional"`
+	// ChallengeAddr is the address the HTTP-01 challenge listener binds to,
+	// started alongside the main server. Defaults to ":80".
+	ChallengeAddr string `json:",default=:80"`
+}
+
+func (conf AutocertConf) manager() *autocert.Manager {
+	cache := conf.Cache
+	if cache == nil {
+		dir := conf.CacheDir
+		if len(dir) == 0 {
+			dir = "autocert"
+		}
+		cache = autocert.DirCache(dir)
+	}
+
+	return &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(conf.Hosts...),
+		Cache:      cache,
+		Email:      conf.Email,
+	}
+}
@@ -0,0 +1,55 @@
+package rest
+
+import (
+	"context"
+	"testing"
+
+	"golang.org/x/crypto/acme/autocert"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAutocertConf_ManagerDefaults(t *testing.T) {
+	conf := AutocertConf{Hosts: []string{"example.com"}, Email: "ops@example.com"}
+	m := conf.manager()
+
+	assert.NotNil(t, m.Prompt)
+	assert.Equal(t, "ops@example.com", m.Email)
+	assert.Nil(t, m.HostPolicy(context.Background(), "example.com"))
+	assert.NotNil(t, m.HostPolicy(context.Background(), "evil.com"))
+
+	dir, ok := m.Cache.(autocert.DirCache)
+	assert.True(t, ok)
+	assert.Equal(t, autocert.DirCache("autocert"), dir)
+}
+
+func TestAutocertConf_ManagerCustomCacheDir(t *testing.T) {
+	conf := AutocertConf{Hosts: []string{"example.com"}, CacheDir: "/var/cache/certs"}
+	m := conf.manager()
+
+	dir, ok := m.Cache.(autocert.DirCache)
+	assert.True(t, ok)
+	assert.Equal(t, autocert.DirCache("/var/cache/certs"), dir)
+}
+
+type stubAutocertCache struct {
+	autocert.Cache
+}
+
+func TestAutocertConf_ManagerExplicitCacheTakesPrecedence(t *testing.T) {
+	cache := stubAutocertCache{}
+	conf := AutocertConf{Hosts: []string{"example.com"}, CacheDir: "/ignored", Cache: cache}
+	m := conf.manager()
+
+	assert.Equal(t, cache, m.Cache)
+}
+
+func TestWithAutocert_ConfiguresEngine(t *testing.T) {
+	conf := AutocertConf{Hosts: []string{"example.com"}, ChallengeAddr: ":8080"}
+	server := &Server{ngin: newEngine(RestConf{})}
+
+	WithAutocert(conf)(server)
+
+	assert.NotNil(t, server.ngin.autocertManager)
+	assert.Equal(t, ":8080", server.ngin.autocertChallenge)
+}
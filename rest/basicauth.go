@@ -0,0 +1,62 @@
+package rest
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+)
+
+// ErrInvalidCredentials is returned by the basic-auth and API-key
+// AuthBackends when a request's credentials don't match.
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// basicAuthBackend is the AuthBackend behind NewBasicAuthBackend.
+type basicAuthBackend struct {
+	username string
+	password string
+}
+
+// NewBasicAuthBackend returns an AuthBackend that authenticates requests
+// carrying HTTP Basic credentials matching username/password, reporting the
+// username back as Claims["sub"].
+func NewBasicAuthBackend(username, password string) AuthBackend {
+	return &basicAuthBackend{username: username, password: password}
+}
+
+func (b *basicAuthBackend) Authenticate(r *http.Request) (Claims, error) {
+	username, password, ok := r.BasicAuth()
+	if !ok ||
+		subtle.ConstantTimeCompare([]byte(username), []byte(b.username)) != 1 ||
+		subtle.ConstantTimeCompare([]byte(password), []byte(b.password)) != 1 {
+		return nil, ErrInvalidCredentials
+	}
+
+	return Claims{"sub": username}, nil
+}
+
+// apiKeyAuthBackend is the AuthBackend behind NewAPIKeyAuthBackend.
+type apiKeyAuthBackend struct {
+	header string
+	keys   map[string]string
+}
+
+// NewAPIKeyAuthBackend returns an AuthBackend that authenticates requests
+// whose header names one of keys, reporting the matched key's value back as
+// Claims["sub"] (typically the owning user or service name).
+func NewAPIKeyAuthBackend(header string, keys map[string]string) AuthBackend {
+	return &apiKeyAuthBackend{header: header, keys: keys}
+}
+
+func (b *apiKeyAuthBackend) Authenticate(r *http.Request) (Claims, error) {
+	key := r.Header.Get(b.header)
+	if len(key) == 0 {
+		return nil, ErrInvalidCredentials
+	}
+
+	sub, ok := b.keys[key]
+	if !ok {
+		return nil, ErrInvalidCredentials
+	}
+
+	return Claims{"sub": sub}, nil
+}
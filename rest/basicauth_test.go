@@ -0,0 +1,55 @@
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBasicAuthBackend_Authenticate(t *testing.T) {
+	backend := NewBasicAuthBackend("admin", "secretpw")
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.SetBasicAuth("admin", "secretpw")
+
+	claims, err := backend.Authenticate(r)
+	assert.Nil(t, err)
+	assert.Equal(t, "admin", claims["sub"])
+}
+
+func TestBasicAuthBackend_AuthenticateWrongPassword(t *testing.T) {
+	backend := NewBasicAuthBackend("admin", "secretpw")
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.SetBasicAuth("admin", "wrong")
+
+	_, err := backend.Authenticate(r)
+	assert.Equal(t, ErrInvalidCredentials, err)
+}
+
+func TestAPIKeyAuthBackend_Authenticate(t *testing.T) {
+	backend := NewAPIKeyAuthBackend("X-Api-Key", map[string]string{
+		"key-1": "service-a",
+	})
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("X-Api-Key", "key-1")
+
+	claims, err := backend.Authenticate(r)
+	assert.Nil(t, err)
+	assert.Equal(t, "service-a", claims["sub"])
+}
+
+func TestAPIKeyAuthBackend_AuthenticateUnknownKey(t *testing.T) {
+	backend := NewAPIKeyAuthBackend("X-Api-Key", map[string]string{
+		"key-1": "service-a",
+	})
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("X-Api-Key", "key-2")
+
+	_, err := backend.Authenticate(r)
+	assert.Equal(t, ErrInvalidCredentials, err)
+}
@@ -0,0 +1,46 @@
+package rest
+
+import (
+	"os"
+
+	"github.com/tal-tech/go-zero/core/service"
+)
+
+// A RestConf is the config for a rest.Server.
+type RestConf struct {
+	service.ServiceConf
+	Host     string `json:",default=0.0.0.0"`
+	Port     int
+	CertFile string `json:",optional"`
+	KeyFile  string `json:",optional"`
+	Verbose  bool   `json:",optional"`
+	MaxConns int    `json:",default=10000"`
+	MaxBytes int64  `json:",default=1048576"`
+	// milliseconds
+	Timeout      int64 `json:",default=3000"`
+	CpuThreshold int64 `json:",default=900,range=[0:1000]"`
+}
+
+// HasTLS tells whether c is configured to serve TLS off CertFile/KeyFile.
+func (c RestConf) HasTLS() bool {
+	return len(c.CertFile) > 0 && len(c.KeyFile) > 0
+}
+
+// validateCertFiles checks that CertFile/KeyFile, when configured, are
+// readable. Called from mainTLSConfig when the main listener starts, so a
+// typo'd path degrades just that listener to brokenTLSHandler instead of
+// panicking deep inside ListenAndServeTLS.
+func (c RestConf) validateCertFiles() error {
+	if !c.HasTLS() {
+		return nil
+	}
+
+	if _, err := os.Stat(c.CertFile); err != nil {
+		return ErrInvalidTLSConfig
+	}
+	if _, err := os.Stat(c.KeyFile); err != nil {
+		return ErrInvalidTLSConfig
+	}
+
+	return nil
+}
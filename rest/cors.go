@@ -0,0 +1,184 @@
+package rest
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// A CORSConf is the config for Cross-Origin Resource Sharing, modeled on
+// gin-contrib/cors. A zero-value CORSConf allows no cross-origin requests,
+// since AllowOrigins is empty.
+type CORSConf struct {
+	// AllowOrigins lists the origins allowed to make cross-origin requests.
+	// An entry may be "*", an exact origin, a single-"*" wildcard such as
+	// "https://*.example.com", or, wrapped in "/.../", a regexp matched
+	// against the full origin, e.g. "/^https://[a-z0-9-]+\\.example\\.com$/".
+	AllowOrigins []string `json:",optional"`
+	// AllowMethods lists the methods allowed in a preflight request.
+	// Defaults to GET, POST, PUT, PATCH, DELETE, HEAD, OPTIONS.
+	AllowMethods []string `json:",optional"`
+	// AllowHeaders lists the request headers allowed in a preflight request.
+	// When empty, a preflight echoes back whatever Access-Control-Request-Headers asked for.
+	AllowHeaders []string `json:",optional"`
+	// ExposeHeaders lists the response headers a browser is allowed to read.
+	ExposeHeaders []string `json:",optional"`
+	// AllowCredentials tells whether the response may be read when the
+	// request was sent with credentials (cookies, HTTP auth).
+	AllowCredentials bool `json:",optional"`
+	// MaxAge is how long, in seconds, a browser may cache a preflight result.
+	MaxAge int64 `json:",optional"`
+}
+
+var defaultCORSMethods = []string{
+	http.MethodGet, http.MethodPost, http.MethodPut,
+	http.MethodPatch, http.MethodDelete, http.MethodHead, http.MethodOptions,
+}
+
+// A corsHandler implements the header injection and preflight handling
+// shared by the per-route CORS middleware and the NotAllowedHandler hook
+// that answers preflight requests to routes that only ever register their
+// real method.
+type corsHandler struct {
+	CORSConf
+	originRegexps []*regexp.Regexp
+}
+
+// newCorsHandler builds a corsHandler from conf, filling in AllowMethods
+// with a sensible default when it's left unset and precompiling any
+// "/regexp/"-style AllowOrigins entries once up front.
+func newCorsHandler(conf CORSConf) *corsHandler {
+	if len(conf.AllowMethods) == 0 {
+		conf.AllowMethods = defaultCORSMethods
+	}
+
+	h := &corsHandler{CORSConf: conf}
+	for _, allowed := range conf.AllowOrigins {
+		if pattern, ok := originRegexpPattern(allowed); ok {
+			h.originRegexps = append(h.originRegexps, regexp.MustCompile(pattern))
+		}
+	}
+
+	return h
+}
+
+// originRegexpPattern reports whether allowed is a "/.../"-wrapped regexp
+// AllowOrigins entry, returning the pattern inside the slashes.
+func originRegexpPattern(allowed string) (string, bool) {
+	if len(allowed) >= 2 && strings.HasPrefix(allowed, "/") && strings.HasSuffix(allowed, "/") {
+		return allowed[1 : len(allowed)-1], true
+	}
+
+	return "", false
+}
+
+// middleware wraps handle so every response, preflight or not, carries the
+// configured Access-Control-* headers for allowed origins.
+func (h *corsHandler) middleware(handle http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		h.setHeaders(w, r.Header.Get("Origin"))
+		handle(w, r)
+	}
+}
+
+// handlePreflight answers an OPTIONS preflight request in full, returning
+// false without writing anything if origin isn't allowed so the caller can
+// fall through to its own not-allowed/not-found behavior.
+func (h *corsHandler) handlePreflight(w http.ResponseWriter, r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if !h.setHeaders(w, origin) {
+		return false
+	}
+
+	header := w.Header()
+	header.Set("Access-Control-Allow-Methods", strings.Join(h.AllowMethods, ", "))
+	if len(h.AllowHeaders) > 0 {
+		header.Set("Access-Control-Allow-Headers", strings.Join(h.AllowHeaders, ", "))
+	} else if reqHeaders := r.Header.Get("Access-Control-Request-Headers"); len(reqHeaders) > 0 {
+		header.Set("Access-Control-Allow-Headers", reqHeaders)
+	}
+	if h.MaxAge > 0 {
+		header.Set("Access-Control-Max-Age", strconv.FormatInt(h.MaxAge, 10))
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return true
+}
+
+// setHeaders writes the Access-Control-Allow-Origin/-Credentials/
+// -Expose-Headers headers shared by preflight and real responses. It
+// reports false, writing nothing, if origin isn't in AllowOrigins.
+func (h *corsHandler) setHeaders(w http.ResponseWriter, origin string) bool {
+	if !h.allowOrigin(origin) {
+		return false
+	}
+
+	header := w.Header()
+	header.Add("Vary", "Origin")
+	if h.AllowCredentials {
+		// the actual origin, never "*", is required once credentials are allowed.
+		header.Set("Access-Control-Allow-Origin", origin)
+		header.Set("Access-Control-Allow-Credentials", "true")
+	} else if contains(h.AllowOrigins, "*") {
+		header.Set("Access-Control-Allow-Origin", "*")
+	} else {
+		header.Set("Access-Control-Allow-Origin", origin)
+	}
+	if len(h.ExposeHeaders) > 0 {
+		header.Set("Access-Control-Expose-Headers", strings.Join(h.ExposeHeaders, ", "))
+	}
+
+	return true
+}
+
+// allowOrigin reports whether origin matches one of h.AllowOrigins: as an
+// exact match, "*", a single-"*" wildcard, or a precompiled "/regexp/" entry.
+func (h *corsHandler) allowOrigin(origin string) bool {
+	if len(origin) == 0 {
+		return false
+	}
+
+	for _, allowed := range h.AllowOrigins {
+		if allowed == "*" || allowed == origin {
+			return true
+		}
+		if _, ok := originRegexpPattern(allowed); ok {
+			continue
+		}
+		if strings.Contains(allowed, "*") && matchOriginWildcard(allowed, origin) {
+			return true
+		}
+	}
+
+	for _, re := range h.originRegexps {
+		if re.MatchString(origin) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchOriginWildcard matches origin against pattern, which contains
+// exactly one "*", e.g. "https://*.example.com".
+func matchOriginWildcard(pattern, origin string) bool {
+	i := strings.Index(pattern, "*")
+	prefix, suffix := pattern[:i], pattern[i+1:]
+	return strings.HasPrefix(origin, prefix) && strings.HasSuffix(origin, suffix)
+}
+
+// isPreflight reports whether r is a CORS preflight request.
+func isPreflight(r *http.Request) bool {
+	return r.Method == http.MethodOptions && len(r.Header.Get("Access-Control-Request-Method")) > 0
+}
+
+func contains(list []string, v string) bool {
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,114 @@
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCorsHandler_AllowOriginExact(t *testing.T) {
+	h := newCorsHandler(CORSConf{AllowOrigins: []string{"https://a.example.com"}})
+
+	assert.True(t, h.allowOrigin("https://a.example.com"))
+	assert.False(t, h.allowOrigin("https://b.example.com"))
+}
+
+func TestCorsHandler_AllowOriginWildcard(t *testing.T) {
+	h := newCorsHandler(CORSConf{AllowOrigins: []string{"https://*.example.com"}})
+
+	assert.True(t, h.allowOrigin("https://a.example.com"))
+	assert.True(t, h.allowOrigin("https://b.example.com"))
+	assert.False(t, h.allowOrigin("https://example.com"))
+	assert.False(t, h.allowOrigin("https://a.example.org"))
+}
+
+func TestCorsHandler_AllowOriginRegexp(t *testing.T) {
+	h := newCorsHandler(CORSConf{AllowOrigins: []string{`/^https:\/\/[a-z0-9-]+\.example\.com$/`}})
+
+	assert.True(t, h.allowOrigin("https://a-1.example.com"))
+	assert.False(t, h.allowOrigin("https://a_1.example.com"))
+	assert.False(t, h.allowOrigin("http://a.example.com"))
+}
+
+func TestCorsHandler_SetHeadersRejectsDisallowedOrigin(t *testing.T) {
+	h := newCorsHandler(CORSConf{AllowOrigins: []string{"https://a.example.com"}})
+	w := httptest.NewRecorder()
+
+	assert.False(t, h.setHeaders(w, "https://evil.example.com"))
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+}
+
+func TestCorsHandler_HandlePreflight(t *testing.T) {
+	h := newCorsHandler(CORSConf{AllowOrigins: []string{"https://a.example.com"}})
+
+	r := httptest.NewRequest(http.MethodOptions, "/", nil)
+	r.Header.Set("Origin", "https://a.example.com")
+	r.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	w := httptest.NewRecorder()
+
+	assert.True(t, isPreflight(r))
+	assert.True(t, h.handlePreflight(w, r))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "https://a.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.NotEmpty(t, w.Header().Get("Access-Control-Allow-Methods"))
+}
+
+func TestCorsHandler_HandlePreflightDisallowedOrigin(t *testing.T) {
+	h := newCorsHandler(CORSConf{AllowOrigins: []string{"https://a.example.com"}})
+
+	r := httptest.NewRequest(http.MethodOptions, "/", nil)
+	r.Header.Set("Origin", "https://evil.example.com")
+	r.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	w := httptest.NewRecorder()
+
+	assert.False(t, h.handlePreflight(w, r))
+}
+
+// fakeRouter is a minimal httpx.Router stand-in that just captures the
+// NotAllowedHandler passed to it.
+type fakeRouter struct {
+	notAllowed http.Handler
+}
+
+func (f *fakeRouter) ServeHTTP(http.ResponseWriter, *http.Request) {}
+func (f *fakeRouter) Handle(string, string, http.Handler) error    { return nil }
+func (f *fakeRouter) SetNotFoundHandler(http.Handler)              {}
+func (f *fakeRouter) SetNotAllowedHandler(handler http.Handler) {
+	f.notAllowed = handler
+}
+
+// TestEngine_BindRoutes_CorsByPathKeyedByMethod guards against corsByPath
+// being keyed by path alone: two routes sharing "/shared" but configured
+// with different CORSConf per method must each answer preflight with their
+// own config instead of one clobbering the other.
+func TestEngine_BindRoutes_CorsByPathKeyedByMethod(t *testing.T) {
+	ng := newEngine(RestConf{})
+	ng.AddRoutes(featuredRoutes{
+		routes: []Route{{Method: http.MethodGet, Path: "/shared", Handler: func(http.ResponseWriter, *http.Request) {}}},
+		cors:   corsSetting{CORSConf: CORSConf{AllowOrigins: []string{"https://get.example.com"}}, enabled: true},
+	})
+	ng.AddRoutes(featuredRoutes{
+		routes: []Route{{Method: http.MethodPost, Path: "/shared", Handler: func(http.ResponseWriter, *http.Request) {}}},
+		cors:   corsSetting{CORSConf: CORSConf{AllowOrigins: []string{"https://post.example.com"}}, enabled: true},
+	})
+
+	rt := &fakeRouter{}
+	assert.Nil(t, ng.bindRoutes(rt))
+	assert.NotNil(t, rt.notAllowed)
+
+	getPreflight := httptest.NewRequest(http.MethodOptions, "/shared", nil)
+	getPreflight.Header.Set("Origin", "https://get.example.com")
+	getPreflight.Header.Set("Access-Control-Request-Method", http.MethodGet)
+	w := httptest.NewRecorder()
+	rt.notAllowed.ServeHTTP(w, getPreflight)
+	assert.Equal(t, "https://get.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+
+	postPreflight := httptest.NewRequest(http.MethodOptions, "/shared", nil)
+	postPreflight.Header.Set("Origin", "https://post.example.com")
+	postPreflight.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	w2 := httptest.NewRecorder()
+	rt.notAllowed.ServeHTTP(w2, postPreflight)
+	assert.Equal(t, "https://post.example.com", w2.Header().Get("Access-Control-Allow-Origin"))
+}
@@ -0,0 +1,497 @@
+package rest
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+
+	"golang.org/x/crypto/acme/autocert"
+
+	"github.com/tal-tech/go-zero/core/logx"
+	"github.com/tal-tech/go-zero/core/proc"
+	"github.com/tal-tech/go-zero/rest/handler"
+	"github.com/tal-tech/go-zero/rest/httpx"
+	"github.com/tal-tech/go-zero/rest/router"
+)
+
+type trustedContextKey struct{}
+
+// An engine wires RestConf, registered routes and RunOption-configured
+// behavior into the http servers that actually get listened on.
+type engine struct {
+	conf                 RestConf
+	routes               []featuredRoutes
+	unauthorizedCallback handler.UnauthorizedCallback
+	unsignedCallback     handler.UnsignedCallback
+	middlewares          []Middleware
+	extraListeners       []listenerConf
+	listenerErr          error
+	tlsConfig            *tls.Config
+	autocertManager      *autocert.Manager
+	autocertChallenge    string
+	globalCORS           *corsHandler
+	corsByPath           map[string]*corsHandler
+	globalRateLimit      *RateLimitConf
+	notAllowedHandler    http.Handler
+}
+
+func newEngine(c RestConf) *engine {
+	return &engine{
+		conf:                 c,
+		unauthorizedCallback: handler.DefaultUnauthorizedCallback,
+		unsignedCallback:     handler.DefaultUnsignedCallback,
+	}
+}
+
+// AddRoutes adds r into the set of routes the engine will bind on Start.
+func (ng *engine) AddRoutes(r featuredRoutes) {
+	ng.routes = append(ng.routes, r)
+}
+
+// use adds the given middleware, applied to every route around its own
+// jwt, signature and rate-limit handling, and inside its cors handling.
+func (ng *engine) use(middleware Middleware) {
+	ng.middlewares = append(ng.middlewares, middleware)
+}
+
+// SetUnauthorizedCallback sets the callback invoked when an AuthBackend
+// (jwt included) rejects a request.
+func (ng *engine) SetUnauthorizedCallback(callback handler.UnauthorizedCallback) {
+	ng.unauthorizedCallback = callback
+}
+
+// SetUnsignedCallback sets the callback invoked when signature verification
+// fails.
+func (ng *engine) SetUnsignedCallback(callback handler.UnsignedCallback) {
+	ng.unsignedCallback = callback
+}
+
+// addListener attaches an additional net.Listener the engine serves
+// alongside the one derived from RestConf.
+func (ng *engine) addListener(lc listenerConf) {
+	ng.extraListeners = append(ng.extraListeners, lc)
+}
+
+// addListenerErr records the first error encountered while preparing an
+// additional listener (e.g. WithUnixSocket failing to bind), surfaced from
+// Start instead of panicking inside a RunOption.
+func (ng *engine) addListenerErr(err error) {
+	if ng.listenerErr == nil {
+		ng.listenerErr = err
+	}
+}
+
+// closeListeners closes every extraListener bound so far, e.g. a
+// WithUnixSocket that succeeded before a later RunOption failed and set
+// listenerErr. Without this, NewServer returning that error early leaks the
+// already-bound listener (and, for a unix socket, its file on disk).
+func (ng *engine) closeListeners() {
+	for _, lc := range ng.extraListeners {
+		lc.ln.Close()
+	}
+}
+
+// setTlsConfig sets the tls.Config used for the main listener, recording
+// ErrInvalidTLSConfig instead if cfg can't be used to start a TLS listener
+// (e.g. ClientAuth requires verification but no ClientCAs were supplied), so
+// NewServer surfaces it up front instead of panicking deep inside
+// ListenAndServeTLS once the server actually starts.
+func (ng *engine) setTlsConfig(cfg *tls.Config) {
+	if err := validateTLSConfig(cfg); err != nil {
+		ng.addListenerErr(err)
+		return
+	}
+
+	ng.tlsConfig = cfg
+}
+
+// setAutocertManager configures the engine to serve TLS certificates issued
+// automatically through m, starting its HTTP-01 challenge listener on
+// challengeAddr alongside the main server.
+func (ng *engine) setAutocertManager(m *autocert.Manager, challengeAddr string) {
+	ng.autocertManager = m
+	ng.autocertChallenge = challengeAddr
+}
+
+// setGlobalCORS configures the CORS conf applied to every route that
+// doesn't set its own via WithCORS.
+func (ng *engine) setGlobalCORS(conf CORSConf) {
+	ng.globalCORS = newCorsHandler(conf)
+}
+
+// setGlobalRateLimit configures the RateLimitConf applied to every request
+// the engine serves, ahead of routing.
+func (ng *engine) setGlobalRateLimit(conf RateLimitConf) {
+	ng.globalRateLimit = &conf
+}
+
+// setNotAllowedHandler records the handler set via WithNotAllowedHandler, so
+// corsNotAllowedHandler can fall back to it instead of a plain 405 once CORS
+// also needs rt's NotAllowedHandler to answer preflight requests.
+func (ng *engine) setNotAllowedHandler(handler http.Handler) {
+	ng.notAllowedHandler = handler
+}
+
+// Start starts serving with the default router.
+func (ng *engine) Start() error {
+	return ng.StartWithRouter(router.NewRouter())
+}
+
+// StartWithRouter starts serving with the given router, binding every
+// registered route onto it first. A broken RestConf.CertFile/KeyFile is
+// deliberately not checked here: mainTLSConfig/serveAll degrade just the
+// main listener to brokenTLSHandler for that case, so a bad cert doesn't
+// tear down every other listener (e.g. a trusted admin unix socket) along
+// with it.
+func (ng *engine) StartWithRouter(rt httpx.Router) error {
+	if ng.listenerErr != nil {
+		return ng.listenerErr
+	}
+	if err := ng.bindRoutes(rt); err != nil {
+		return err
+	}
+
+	mainLn, err := net.Listen("tcp", fmt.Sprintf("%s:%d", ng.conf.Host, ng.conf.Port))
+	if err != nil {
+		return err
+	}
+
+	return ng.serveAll(ng.wrapGlobalRateLimit(rt), mainLn)
+}
+
+// wrapGlobalRateLimit wraps rt with the WithGlobalRateLimit conf, if any, so
+// a flood is turned away before it ever reaches routing or auth. A no-op
+// when WithGlobalRateLimit wasn't used.
+//
+// WithGlobalCORS, if set, is applied around the limiter rather than inside
+// it, so a request rejected with 429 here still carries the
+// Access-Control-* headers a browser needs to surface the 429 instead of
+// reporting an opaque network error. A route's own WithCORS can't be
+// consulted yet, since rejection happens ahead of routing.
+func (ng *engine) wrapGlobalRateLimit(rt http.Handler) http.Handler {
+	if ng.globalRateLimit == nil {
+		return rt
+	}
+
+	handle := rateLimitHandler(*ng.globalRateLimit, "ratelimit:global", rt.ServeHTTP)
+	if ng.globalCORS != nil {
+		handle = ng.globalCORS.middleware(handle)
+	}
+
+	return handle
+}
+
+// serveAll serves rt on the main listener plus every listener attached via
+// WithListener/WithUnixSocket, concurrently, returning the first error any
+// of them reports. Each listener is served by its own *http.Server, and each
+// registers a proc shutdown listener so a SIGTERM gracefully drains every one
+// of them together instead of dropping in-flight requests.
+func (ng *engine) serveAll(rt http.Handler, mainLn net.Listener) error {
+	listeners := append([]listenerConf{{ln: mainLn}}, ng.extraListeners...)
+	errs := make(chan error, len(listeners)+1)
+
+	mainTLSConfig, tlsErr := ng.mainTLSConfig()
+
+	for i, lc := range listeners {
+		lc := lc
+		srv := &http.Server{Handler: ng.handlerFor(rt, lc)}
+		// only the main listener (index 0, derived from RestConf) serves
+		// the server-wide tls.Config/autocert manager; additional
+		// listeners such as an admin unix socket stay plaintext.
+		switch {
+		case i == 0 && tlsErr != nil:
+			// a broken cert/CA shouldn't tear down every other listener,
+			// e.g. a trusted admin unix socket; degrade just this one to a
+			// handler that reports the problem instead of returning an
+			// error here that would reach handleError's panic. Logged since
+			// nothing else would otherwise signal that the main listener is
+			// serving nothing but 500s.
+			logx.Errorf("main listener TLS config is broken, degrading to a 500 handler: %v", tlsErr)
+			srv.Handler = brokenTLSHandler(tlsErr)
+		case i == 0 && mainTLSConfig != nil:
+			srv.TLSConfig = mainTLSConfig
+		}
+
+		waitForCalled := proc.AddShutdownListener(func() {
+			if err := srv.Shutdown(context.Background()); err != nil {
+				logx.Error(err)
+			}
+		})
+
+		go func() {
+			defer waitForCalled()
+
+			var err error
+			if srv.TLSConfig != nil {
+				err = srv.ServeTLS(lc.ln, "", "")
+			} else {
+				err = srv.Serve(lc.ln)
+			}
+			errs <- err
+		}()
+	}
+
+	if ng.autocertManager != nil {
+		go func() {
+			addr := ng.autocertChallenge
+			if len(addr) == 0 {
+				addr = ":80"
+			}
+			errs <- http.ListenAndServe(addr, ng.autocertManager.HTTPHandler(nil))
+		}()
+	}
+
+	return <-errs
+}
+
+// mainTLSConfig returns the tls.Config the main listener should serve with,
+// preferring the autocert manager over an explicit WithTLSConfig over
+// RestConf.CertFile/KeyFile when more than one is set. setTlsConfig already
+// rejects a broken explicit WithTLSConfig up front, but an unreadable or
+// unparsable RestConf.CertFile/KeyFile is only ever discovered here, on
+// purpose: returning the error lets serveAll degrade just the main listener
+// to brokenTLSHandler instead of failing StartWithRouter and taking every
+// other listener down with it.
+func (ng *engine) mainTLSConfig() (*tls.Config, error) {
+	if ng.autocertManager != nil {
+		cfg := ng.autocertManager.TLSConfig()
+		cfg.NextProtos = []string{"acme-tls/1", "h2", "http/1.1"}
+		return cfg, nil
+	}
+
+	if ng.tlsConfig != nil {
+		return ng.tlsConfig, nil
+	}
+
+	if !ng.conf.HasTLS() {
+		return nil, nil
+	}
+
+	if err := ng.conf.validateCertFiles(); err != nil {
+		return nil, err
+	}
+
+	cert, err := tls.LoadX509KeyPair(ng.conf.CertFile, ng.conf.KeyFile)
+	if err != nil {
+		return nil, ErrInvalidTLSConfig
+	}
+
+	return &tls.Config{Certificates: []tls.Certificate{cert}}, nil
+}
+
+// validateTLSConfig catches the common ways a *tls.Config only ever used to
+// fail once a handshake is attempted deep inside ListenAndServeTLS, e.g.
+// ClientAuth requiring verification with no ClientCAs to verify against.
+func validateTLSConfig(cfg *tls.Config) error {
+	switch cfg.ClientAuth {
+	case tls.RequireAndVerifyClientCert, tls.VerifyClientCertIfGiven:
+		if cfg.ClientCAs == nil {
+			return ErrInvalidTLSConfig
+		}
+	}
+
+	return nil
+}
+
+// brokenTLSHandler is served, over plain HTTP, on a listener whose TLS
+// config turned out to be unusable, reporting the problem on every request
+// instead of taking the whole process (and every other listener) down.
+func brokenTLSHandler(err error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "broken TLS config: "+err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// handlerFor wraps rt so that requests accepted on a trusted listener carry
+// a marker in their context, letting per-route auth middleware (jwt,
+// signature) bypass credential checks for it.
+func (ng *engine) handlerFor(rt http.Handler, lc listenerConf) http.Handler {
+	if !lc.trusted {
+		return rt
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rt.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), trustedContextKey{}, true)))
+	})
+}
+
+func isTrusted(r *http.Request) bool {
+	trusted, _ := r.Context().Value(trustedContextKey{}).(bool)
+	return trusted
+}
+
+// bindRoutes registers every featuredRoutes onto rt, wrapping each route's
+// handler with its own rate-limit/jwt/signature behavior, then the global
+// middlewares added via Server.Use, then its cors behavior outermost. Routes
+// with CORS enabled (their
+// own via WithCORS, or the server-wide WithGlobalCORS) also get their path
+// recorded so preflight requests, which never match a registered OPTIONS
+// route, can be answered from rt's NotAllowedHandler instead of falling
+// through to a plain 405 — see corsNotAllowedHandler.
+func (ng *engine) bindRoutes(rt httpx.Router) error {
+	for _, fr := range ng.routes {
+		cors := ng.corsHandlerFor(fr)
+		for _, route := range fr.routes {
+			if cors != nil {
+				if ng.corsByPath == nil {
+					ng.corsByPath = make(map[string]*corsHandler)
+				}
+				ng.corsByPath[route.Method+" "+route.Path] = cors
+			}
+
+			// bindRateLimit is the innermost wrapper, so it runs after
+			// bindAuth and a WithRateLimit keyed on a jwt claim (ClaimKey)
+			// sees the Claims bindAuth populates right before calling it.
+			handle := ng.bindRateLimit(fr, route, route.Handler)
+			handle = ng.bindAuth(fr, handle)
+			handle = ng.bindSignature(fr, handle)
+			handle = ng.bindMiddlewares(handle)
+			handle = ng.bindCORS(cors, handle)
+			if err := rt.Handle(route.Method, route.Path, handle); err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(ng.corsByPath) > 0 {
+		rt.SetNotAllowedHandler(ng.corsNotAllowedHandler())
+	}
+
+	return nil
+}
+
+// corsHandlerFor returns the corsHandler that should apply to fr's routes:
+// its own WithCORS config if set, falling back to the server-wide one from
+// WithGlobalCORS, or nil if neither applies.
+func (ng *engine) corsHandlerFor(fr featuredRoutes) *corsHandler {
+	if fr.cors.enabled {
+		return newCorsHandler(fr.cors.CORSConf)
+	}
+
+	return ng.globalCORS
+}
+
+// bindCORS wraps handle so its response carries cors's Access-Control-*
+// headers, a no-op if cors is nil.
+func (ng *engine) bindCORS(cors *corsHandler, handle http.HandlerFunc) http.HandlerFunc {
+	if cors == nil {
+		return handle
+	}
+
+	return cors.middleware(handle)
+}
+
+// bindRateLimit wraps handle with the route's WithRateLimit conf, keyed by
+// route so two routes sharing a RateLimitConf value still track separate
+// quota. A no-op if the route didn't set one.
+func (ng *engine) bindRateLimit(fr featuredRoutes, route Route, handle http.HandlerFunc) http.HandlerFunc {
+	if !fr.rateLimit.enabled {
+		return handle
+	}
+
+	return rateLimitHandler(fr.rateLimit.RateLimitConf, "ratelimit:"+route.Method+":"+route.Path, handle)
+}
+
+// corsNotAllowedHandler answers a preflight OPTIONS request against a
+// method+path that has CORS configured with that route's headers instead of
+// falling through to the plain not-allowed response. The lookup is keyed by
+// Access-Control-Request-Method, the preflighted method, not the request's
+// own OPTIONS, so two routes sharing a path but configured with different
+// CORSConf (e.g. GET picking up WithGlobalCORS, POST set explicitly via
+// WithCORS) each get their own config instead of one clobbering the other in
+// corsByPath. Every other request, and any preflight whose origin isn't
+// allowed, falls through to whatever was set via WithNotAllowedHandler, or a
+// plain 405 if nothing was.
+func (ng *engine) corsNotAllowedHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isPreflight(r) {
+			method := r.Header.Get("Access-Control-Request-Method")
+			if cors, ok := ng.corsByPath[method+" "+r.URL.Path]; ok && cors.handlePreflight(w, r) {
+				return
+			}
+		}
+
+		if ng.notAllowedHandler != nil {
+			ng.notAllowedHandler.ServeHTTP(w, r)
+			return
+		}
+
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+	})
+}
+
+// bindSignature wraps handle with the route's request signature
+// verification, if enabled via WithSignature, unless the request arrived on
+// a trusted listener. A PrivateKeys entry whose key file can't be read fails
+// closed for every request on the route rather than silently skipping
+// verification.
+func (ng *engine) bindSignature(fr featuredRoutes, handle http.HandlerFunc) http.HandlerFunc {
+	if !fr.signature.enabled {
+		return handle
+	}
+
+	verifier, err := newSignatureVerifier(fr.signature.SignatureConf)
+	if err != nil {
+		return func(w http.ResponseWriter, r *http.Request) {
+			ng.unsignedCallback(w, r, err)
+		}
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if isTrusted(r) {
+			handle(w, r)
+			return
+		}
+
+		if err := verifier.verify(r); err != nil {
+			ng.unsignedCallback(w, r, err)
+			return
+		}
+
+		handle(w, r)
+	}
+}
+
+func (ng *engine) bindMiddlewares(handle http.HandlerFunc) http.HandlerFunc {
+	for i := len(ng.middlewares) - 1; i >= 0; i-- {
+		handle = ng.middlewares[i](handle)
+	}
+
+	return handle
+}
+
+// bindAuth wraps handle with the route's jwt authentication, unless the
+// request arrived on a trusted listener. jwt.backend is consulted first;
+// jwt.secret/prevSecret remain populated by WithJwt/WithJwtTransition for
+// backward compatibility and are used to build the HS256 backend on the fly
+// when no backend was set explicitly.
+func (ng *engine) bindAuth(fr featuredRoutes, handle http.HandlerFunc) http.HandlerFunc {
+	if !fr.jwt.enabled {
+		return handle
+	}
+
+	backend := fr.jwt.backend
+	if backend == nil && len(fr.jwt.secret) > 0 {
+		backend = newJwtAuthBackend(fr.jwt.secret, fr.jwt.prevSecret)
+	}
+	if backend == nil {
+		return handle
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if isTrusted(r) {
+			handle(w, r)
+			return
+		}
+
+		claims, err := backend.Authenticate(r)
+		if err != nil {
+			ng.unauthorizedCallback(w, r, err)
+			return
+		}
+
+		handle(w, withClaims(r, claims))
+	}
+}
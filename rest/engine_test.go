@@ -0,0 +1,104 @@
+package rest
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateTLSConfig(t *testing.T) {
+	assert.Nil(t, validateTLSConfig(&tls.Config{}))
+	assert.Equal(t, ErrInvalidTLSConfig, validateTLSConfig(&tls.Config{ClientAuth: tls.RequireAndVerifyClientCert}))
+	assert.Equal(t, ErrInvalidTLSConfig, validateTLSConfig(&tls.Config{ClientAuth: tls.VerifyClientCertIfGiven}))
+	assert.Nil(t, validateTLSConfig(&tls.Config{
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		ClientCAs:  x509.NewCertPool(),
+	}))
+}
+
+func TestBrokenTLSHandler(t *testing.T) {
+	h := brokenTLSHandler(ErrInvalidTLSConfig)
+
+	w := httptest.NewRecorder()
+	h(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
+
+func TestEngine_MainTLSConfig_NoTLS(t *testing.T) {
+	ng := newEngine(RestConf{})
+
+	cfg, err := ng.mainTLSConfig()
+	assert.Nil(t, cfg)
+	assert.Nil(t, err)
+}
+
+func TestEngine_MainTLSConfig_BadCertFile(t *testing.T) {
+	ng := newEngine(RestConf{CertFile: "/nonexistent/cert.pem", KeyFile: "/nonexistent/key.pem"})
+
+	cfg, err := ng.mainTLSConfig()
+	assert.Nil(t, cfg)
+	assert.Equal(t, ErrInvalidTLSConfig, err)
+}
+
+func TestEngine_CloseListeners(t *testing.T) {
+	ng := newEngine(RestConf{})
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	ng.addListener(listenerConf{ln: ln})
+
+	ng.closeListeners()
+
+	_, err = net.Dial("tcp", ln.Addr().String())
+	assert.NotNil(t, err)
+}
+
+// TestEngine_BadMainCertDoesNotTakeDownOtherListeners is the scenario named
+// in the chunk0-4 request: an unreadable RestConf.CertFile/KeyFile must
+// degrade only the main listener to brokenTLSHandler, leaving every other
+// listener (e.g. a trusted admin unix socket from WithUnixSocket) serving
+// normally instead of StartWithRouter failing and tearing all of them down.
+func TestEngine_BadMainCertDoesNotTakeDownOtherListeners(t *testing.T) {
+	sockPath := filepath.Join(t.TempDir(), "admin.sock")
+	adminLn, err := net.Listen("unix", sockPath)
+	assert.Nil(t, err)
+
+	ng := newEngine(RestConf{
+		Host:     "127.0.0.1",
+		Port:     0,
+		CertFile: "/nonexistent/cert.pem",
+		KeyFile:  "/nonexistent/key.pem",
+	})
+	ng.addListener(listenerConf{ln: adminLn})
+	ng.AddRoutes(featuredRoutes{routes: []Route{{
+		Method: http.MethodGet,
+		Path:   "/",
+		Handler: func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		},
+	}}})
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- ng.Start() }()
+
+	// the admin listener should still be alive and accepting connections
+	// while the main listener is off serving brokenTLSHandler.
+	assert.Eventually(t, func() bool {
+		conn, err := net.Dial("unix", sockPath)
+		if err != nil {
+			return false
+		}
+		conn.Close()
+		return true
+	}, time.Second, 10*time.Millisecond)
+
+	adminLn.Close()
+	assert.Equal(t, http.ErrServerClosed, <-errCh)
+}
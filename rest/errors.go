@@ -0,0 +1,11 @@
+package rest
+
+import "errors"
+
+// ErrInvalidTLSConfig is returned from NewServer/Start when a *tls.Config
+// passed via WithTLSConfig can't be used to start a TLS listener, e.g.
+// ClientAuth requires verification but no ClientCAs were provided. A
+// CertFile/KeyFile pair in RestConf that can't be read or parsed doesn't fail
+// Start; the main listener instead serves brokenTLSHandler, which reports
+// this error on every request.
+var ErrInvalidTLSConfig = errors.New("invalid tls config")
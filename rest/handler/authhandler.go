@@ -0,0 +1,25 @@
+package handler
+
+import "net/http"
+
+type (
+	// UnauthorizedCallback is invoked when an AuthBackend (jwt included)
+	// rejects a request, receiving the error it returned.
+	UnauthorizedCallback func(w http.ResponseWriter, r *http.Request, err error)
+
+	// UnsignedCallback is invoked when request signature verification fails,
+	// receiving the error it returned.
+	UnsignedCallback func(w http.ResponseWriter, r *http.Request, err error)
+)
+
+// DefaultUnauthorizedCallback is the UnauthorizedCallback used when none is
+// set via WithUnauthorizedCallback, writing a bare 401.
+func DefaultUnauthorizedCallback(w http.ResponseWriter, r *http.Request, err error) {
+	w.WriteHeader(http.StatusUnauthorized)
+}
+
+// DefaultUnsignedCallback is the UnsignedCallback used when none is set via
+// WithUnsignedCallback, writing a bare 401.
+func DefaultUnsignedCallback(w http.ResponseWriter, r *http.Request, err error) {
+	w.WriteHeader(http.StatusUnauthorized)
+}
@@ -0,0 +1,222 @@
+package rest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// ErrKeyNotFound is returned when a JWT's kid doesn't match any key in the
+// JWKS document fetched from a jwksAuthBackend's JwksURL.
+var ErrKeyNotFound = errors.New("no matching key found in jwks")
+
+// ErrUnexpectedSigningMethod is returned when a JWT's alg header isn't an
+// RSA (RS256/RS384/RS512) or ECDSA (ES256/ES384/ES512) method, instead of
+// handing back a key for whatever alg an attacker
+// asked for. Without this check a token signed with HS256 using the RSA
+// public key's bytes as the HMAC secret would verify successfully, since
+// the public key is, by definition, public.
+var ErrUnexpectedSigningMethod = errors.New("unexpected jwt signing method")
+
+// jwksAuthBackend is the AuthBackend behind NewJwksAuthBackend, authenticating
+// RS256/ES256-signed JWTs against public keys published as a JWKS document,
+// e.g. by Auth0/Keycloak/Google.
+type jwksAuthBackend struct {
+	jwksURL         string
+	refreshInterval time.Duration
+	httpClient      *http.Client
+
+	lock      sync.Mutex
+	keys      map[string]interface{}
+	fetchedAt time.Time
+}
+
+// NewJwksAuthBackend returns an AuthBackend for RS256/ES256 JWTs whose
+// public keys are published at jwksURL, re-fetched at most once every
+// refreshInterval so a key rotation on the issuer's side is picked up
+// without restarting the service.
+func NewJwksAuthBackend(jwksURL string, refreshInterval time.Duration) AuthBackend {
+	return &jwksAuthBackend{
+		jwksURL:         jwksURL,
+		refreshInterval: refreshInterval,
+		httpClient:      http.DefaultClient,
+	}
+}
+
+func (b *jwksAuthBackend) Authenticate(r *http.Request) (Claims, error) {
+	raw := extractBearerToken(r)
+	if len(raw) == 0 {
+		return nil, ErrNoTokenFound
+	}
+
+	token, err := jwt.Parse(raw, b.keyFunc)
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid {
+		return nil, jwt.ErrSignatureInvalid
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, jwt.ErrSignatureInvalid
+	}
+
+	return Claims(claims), nil
+}
+
+func (b *jwksAuthBackend) keyFunc(token *jwt.Token) (interface{}, error) {
+	switch token.Method.(type) {
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodECDSA:
+	default:
+		return nil, ErrUnexpectedSigningMethod
+	}
+
+	kid, _ := token.Header["kid"].(string)
+
+	keys, err := b.keySet()
+	if err != nil {
+		return nil, err
+	}
+
+	key, ok := keys[kid]
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+
+	return key, nil
+}
+
+// keySet returns the cached key set, refreshing it from jwksURL first if
+// it's empty or older than refreshInterval. A refresh that fails serves the
+// stale set, if any, rather than failing every request while the JWKS
+// endpoint is temporarily down, and waits another refreshInterval before
+// retrying.
+func (b *jwksAuthBackend) keySet() (map[string]interface{}, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.keys != nil && time.Since(b.fetchedAt) < b.refreshInterval {
+		return b.keys, nil
+	}
+
+	keys, err := b.fetchKeySet()
+	if err != nil {
+		if b.keys != nil {
+			b.fetchedAt = time.Now()
+			return b.keys, nil
+		}
+		return nil, err
+	}
+
+	b.keys = keys
+	b.fetchedAt = time.Now()
+
+	return b.keys, nil
+}
+
+type jwksDocument struct {
+	Keys []jwk `json:"keys"`
+}
+
+type jwk struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (b *jwksAuthBackend) fetchKeySet() (map[string]interface{}, error) {
+	resp, err := b.httpClient.Get(b.jwksURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var doc jwksDocument
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]interface{}, len(doc.Keys))
+	for _, k := range doc.Keys {
+		key, err := k.publicKey()
+		if err != nil {
+			// an unsupported or malformed entry shouldn't keep every other
+			// key in the set from being usable.
+			continue
+		}
+
+		keys[k.Kid] = key
+	}
+
+	return keys, nil
+}
+
+func (k jwk) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		return k.rsaPublicKey()
+	case "EC":
+		return k.ecPublicKey()
+	default:
+		return nil, fmt.Errorf("unsupported jwk key type %q", k.Kty)
+	}
+}
+
+func (k jwk) rsaPublicKey() (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, err
+	}
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: int(new(big.Int).SetBytes(e).Int64()),
+	}, nil
+}
+
+var jwkCurves = map[string]elliptic.Curve{
+	"P-256": elliptic.P256(),
+	"P-384": elliptic.P384(),
+	"P-521": elliptic.P521(),
+}
+
+func (k jwk) ecPublicKey() (*ecdsa.PublicKey, error) {
+	curve, ok := jwkCurves[k.Crv]
+	if !ok {
+		return nil, fmt.Errorf("unsupported jwk curve %q", k.Crv)
+	}
+
+	x, err := base64.RawURLEncoding.DecodeString(k.X)
+	if err != nil {
+		return nil, err
+	}
+	y, err := base64.RawURLEncoding.DecodeString(k.Y)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ecdsa.PublicKey{
+		Curve: curve,
+		X:     new(big.Int).SetBytes(x),
+		Y:     new(big.Int).SetBytes(y),
+	}, nil
+}
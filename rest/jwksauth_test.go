@@ -0,0 +1,77 @@
+package rest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestJwksServer(t *testing.T, kid string, pub *rsa.PublicKey) *httptest.Server {
+	t.Helper()
+
+	doc := jwksDocument{Keys: []jwk{{
+		Kid: kid,
+		Kty: "RSA",
+		N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+	}}}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Nil(t, json.NewEncoder(w).Encode(doc))
+	}))
+}
+
+func TestJwksAuthBackend_Authenticate(t *testing.T) {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+
+	srv := newTestJwksServer(t, "key-1", &priv.PublicKey)
+	defer srv.Close()
+
+	backend := NewJwksAuthBackend(srv.URL, time.Minute)
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{"sub": "test-user"})
+	token.Header["kid"] = "key-1"
+	signed, err := token.SignedString(priv)
+	assert.Nil(t, err)
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Authorization", "Bearer "+signed)
+
+	claims, err := backend.Authenticate(r)
+	assert.Nil(t, err)
+	assert.Equal(t, "test-user", claims["sub"])
+}
+
+func TestJwksAuthBackend_AuthenticateRejectsAlgConfusion(t *testing.T) {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+
+	srv := newTestJwksServer(t, "key-1", &priv.PublicKey)
+	defer srv.Close()
+
+	backend := NewJwksAuthBackend(srv.URL, time.Minute)
+
+	// an attacker who knows the RSA public key signs an HS256 token using
+	// the public key's bytes as the HMAC secret; keyFunc must reject the
+	// signing method before it ever hands that key back.
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "attacker"})
+	token.Header["kid"] = "key-1"
+	signed, err := token.SignedString(priv.PublicKey.N.Bytes())
+	assert.Nil(t, err)
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Authorization", "Bearer "+signed)
+
+	_, err = backend.Authenticate(r)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,66 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// ErrNoTokenFound is returned by jwtAuthBackend when the request carries no
+// bearer token at all.
+var ErrNoTokenFound = errors.New("no token found in request")
+
+// jwtAuthBackend is the AuthBackend behind WithJwt/WithJwtTransition, kept
+// as the default HS256 implementation for backwards compatibility.
+type jwtAuthBackend struct {
+	secret     string
+	prevSecret string
+}
+
+func newJwtAuthBackend(secret, prevSecret string) *jwtAuthBackend {
+	return &jwtAuthBackend{
+		secret:     secret,
+		prevSecret: prevSecret,
+	}
+}
+
+func (b *jwtAuthBackend) Authenticate(r *http.Request) (Claims, error) {
+	raw := extractBearerToken(r)
+	if len(raw) == 0 {
+		return nil, ErrNoTokenFound
+	}
+
+	token, err := jwt.Parse(raw, func(token *jwt.Token) (interface{}, error) {
+		return []byte(b.secret), nil
+	})
+	if err != nil && len(b.prevSecret) > 0 {
+		token, err = jwt.Parse(raw, func(token *jwt.Token) (interface{}, error) {
+			return []byte(b.prevSecret), nil
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid {
+		return nil, jwt.ErrSignatureInvalid
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, jwt.ErrSignatureInvalid
+	}
+
+	return Claims(claims), nil
+}
+
+func extractBearerToken(r *http.Request) string {
+	const prefix = "Bearer "
+
+	auth := r.Header.Get("Authorization")
+	if len(auth) <= len(prefix) || auth[:len(prefix)] != prefix {
+		return ""
+	}
+
+	return auth[len(prefix):]
+}
@@ -0,0 +1,23 @@
+package rest
+
+import "net"
+
+type (
+	// ListenerOption customizes how an additionally attached net.Listener is served.
+	ListenerOption func(*listenerConf)
+
+	listenerConf struct {
+		ln      net.Listener
+		trusted bool
+	}
+)
+
+// WithTrustedListener marks the listener as trusted, so that authentication
+// middlewares such as jwt and signature verification are bypassed for
+// requests accepted on it. This is meant for internal admin sockets that
+// tooling connects to without credentials.
+func WithTrustedListener() ListenerOption {
+	return func(lc *listenerConf) {
+		lc.trusted = true
+	}
+}
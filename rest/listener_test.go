@@ -0,0 +1,79 @@
+package rest
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithListener_AddsExtraListener(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer ln.Close()
+
+	server := &Server{ngin: newEngine(RestConf{})}
+	WithListener(ln)(server)
+
+	assert.Len(t, server.ngin.extraListeners, 1)
+	assert.False(t, server.ngin.extraListeners[0].trusted)
+}
+
+func TestWithListener_Trusted(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer ln.Close()
+
+	server := &Server{ngin: newEngine(RestConf{})}
+	WithListener(ln, WithTrustedListener())(server)
+
+	assert.True(t, server.ngin.extraListeners[0].trusted)
+}
+
+func TestWithUnixSocket_BindsAndChmods(t *testing.T) {
+	sockPath := filepath.Join(t.TempDir(), "admin.sock")
+
+	server := &Server{ngin: newEngine(RestConf{})}
+	WithUnixSocket(sockPath, 0o600)(server)
+	defer server.ngin.closeListeners()
+
+	assert.Nil(t, server.ngin.listenerErr)
+	assert.Len(t, server.ngin.extraListeners, 1)
+
+	info, err := os.Stat(sockPath)
+	assert.Nil(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+}
+
+func TestWithUnixSocket_BadPathRecordsListenerErr(t *testing.T) {
+	server := &Server{ngin: newEngine(RestConf{})}
+	WithUnixSocket("/nonexistent-dir/admin.sock", 0o600)(server)
+
+	assert.NotNil(t, server.ngin.listenerErr)
+	assert.Empty(t, server.ngin.extraListeners)
+}
+
+func TestEngine_HandlerForTrustedListener(t *testing.T) {
+	ng := newEngine(RestConf{})
+	var gotTrusted bool
+	rt := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotTrusted = isTrusted(r)
+	})
+
+	handler := ng.handlerFor(rt, listenerConf{trusted: true})
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.True(t, gotTrusted)
+
+	handler = ng.handlerFor(rt, listenerConf{trusted: false})
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.False(t, gotTrusted)
+}
+
+func TestIsTrusted_NoMarker(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	assert.False(t, isTrusted(r))
+}
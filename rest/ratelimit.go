@@ -0,0 +1,217 @@
+package rest
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/tal-tech/go-zero/core/collection"
+	"github.com/tal-tech/go-zero/core/limit"
+	"github.com/tal-tech/go-zero/core/stores/redis"
+)
+
+// tokenBucketIdleExpire is how long an in-process limiter may sit unused
+// before tokenBucketLimiter evicts it. Client-controlled keys (HeaderKey,
+// ClaimKey, or just distinct remote IPs) would otherwise let a caller grow
+// the limiter set without bound, turning the rate limiter itself into a DoS
+// vector.
+const tokenBucketIdleExpire = 10 * time.Minute
+
+// A RateLimitKeyFunc extracts the identity a rate limit is tracked per from
+// a request, e.g. remote IP, an AuthBackend claim, or a header value.
+type RateLimitKeyFunc func(r *http.Request) string
+
+// RemoteIPKey is the default RateLimitKeyFunc, bucketing requests by the
+// host part of r.RemoteAddr.
+func RemoteIPKey(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+// HeaderKey returns a RateLimitKeyFunc that buckets requests by header's value.
+func HeaderKey(header string) RateLimitKeyFunc {
+	return func(r *http.Request) string {
+		return r.Header.Get(header)
+	}
+}
+
+// ClaimKey returns a RateLimitKeyFunc that buckets requests by the named
+// Claims entry an AuthBackend populated on the request, e.g. the jwt
+// subject. Requests with no Claims all share the same empty-string bucket.
+func ClaimKey(claim string) RateLimitKeyFunc {
+	return func(r *http.Request) string {
+		claims, ok := ClaimsFromContext(r)
+		if !ok {
+			return ""
+		}
+
+		v, _ := claims[claim].(string)
+		return v
+	}
+}
+
+// A RateLimitConf configures WithRateLimit/WithGlobalRateLimit.
+type RateLimitConf struct {
+	// Rate is the number of requests allowed per Period.
+	Rate float64
+	// Burst is the in-process token bucket's burst capacity above Rate.
+	// Ignored by the Redis-backed strategy. Defaults to 1.
+	Burst int `json:",optional"`
+	// Period is the window Rate applies to. Defaults to time.Second.
+	Period time.Duration `json:",optional"`
+	// KeyFunc extracts the identity each request is limited by.
+	// Defaults to RemoteIPKey.
+	KeyFunc RateLimitKeyFunc `json:"-"`
+	// Redis, when set, shares quota across every instance via a fixed
+	// window of Period keyed in redis, instead of an in-process token bucket.
+	// The count resets at each window boundary; the window does not slide.
+	Redis *redis.Redis `json:"-"`
+}
+
+func (c RateLimitConf) period() time.Duration {
+	if c.Period > 0 {
+		return c.Period
+	}
+
+	return time.Second
+}
+
+func (c RateLimitConf) keyFunc() RateLimitKeyFunc {
+	if c.KeyFunc != nil {
+		return c.KeyFunc
+	}
+
+	return RemoteIPKey
+}
+
+// a rateLimiter checks and consumes one unit of quota for key, reporting
+// whether the request is allowed and, if not, how long until it would be.
+type rateLimiter interface {
+	Allow(key string) (allowed bool, retryAfter time.Duration)
+}
+
+// newRateLimiter builds the in-process token bucket limiter, or the
+// redis-backed fixed window limiter when conf.Redis is set.
+func newRateLimiter(conf RateLimitConf, keyPrefix string) rateLimiter {
+	if conf.Redis != nil {
+		return newPeriodRateLimiter(conf, keyPrefix)
+	}
+
+	return newTokenBucketLimiter(conf)
+}
+
+// tokenBucketLimiter is the in-process RateLimitConf strategy, keeping one
+// golang.org/x/time/rate.Limiter per observed key in a cache that evicts
+// entries idle for longer than tokenBucketIdleExpire instead of retaining
+// every key ever seen.
+type tokenBucketLimiter struct {
+	rate  rate.Limit
+	burst int
+
+	cache *collection.Cache
+}
+
+func newTokenBucketLimiter(conf RateLimitConf) *tokenBucketLimiter {
+	burst := conf.Burst
+	if burst <= 0 {
+		burst = 1
+	}
+
+	cache, err := collection.NewCache(tokenBucketIdleExpire)
+	if err != nil {
+		// only fails on a bad CacheOption, and none are passed above.
+		panic(err)
+	}
+
+	return &tokenBucketLimiter{
+		rate:  rate.Limit(conf.Rate / conf.period().Seconds()),
+		burst: burst,
+		cache: cache,
+	}
+}
+
+func (l *tokenBucketLimiter) Allow(key string) (bool, time.Duration) {
+	val, _ := l.cache.Take(key, func() (interface{}, error) {
+		return rate.NewLimiter(l.rate, l.burst), nil
+	})
+	limiter := val.(*rate.Limiter)
+
+	res := limiter.Reserve()
+	if !res.OK() {
+		return false, 0
+	}
+	if delay := res.Delay(); delay > 0 {
+		res.Cancel()
+		return false, delay
+	}
+
+	return true, 0
+}
+
+// periodRateLimiter is the redis-backed RateLimitConf strategy, sharing
+// quota for a key across every instance via core/limit.PeriodLimit's
+// fixed-window counter.
+type periodRateLimiter struct {
+	period  time.Duration
+	limiter *limit.PeriodLimit
+}
+
+func newPeriodRateLimiter(conf RateLimitConf, keyPrefix string) *periodRateLimiter {
+	period := conf.period()
+
+	return &periodRateLimiter{
+		period:  period,
+		limiter: limit.NewPeriodLimit(int(period.Seconds()), int(conf.Rate), conf.Redis, keyPrefix),
+	}
+}
+
+func (l *periodRateLimiter) Allow(key string) (bool, time.Duration) {
+	code, err := l.limiter.Take(key)
+	if err != nil {
+		// a redis hiccup shouldn't take the route down; fail open.
+		return true, 0
+	}
+
+	if code == limit.OverQuota {
+		return false, l.period
+	}
+
+	return true, 0
+}
+
+// rateLimitHandler wraps handle so requests exceeding conf's quota, as
+// tracked per conf.KeyFunc (default RemoteIPKey) under keyPrefix, get a 429
+// with Retry-After and X-RateLimit-* headers instead of reaching handle.
+func rateLimitHandler(conf RateLimitConf, keyPrefix string, handle http.HandlerFunc) http.HandlerFunc {
+	limiter := newRateLimiter(conf, keyPrefix)
+	keyFunc := conf.keyFunc()
+	limitHeader := strconv.FormatFloat(conf.Rate, 'g', -1, 64)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		allowed, retryAfter := limiter.Allow(keyFunc(r))
+
+		header := w.Header()
+		header.Set("X-RateLimit-Limit", limitHeader)
+
+		if !allowed {
+			seconds := int(retryAfter.Seconds())
+			if seconds < 1 {
+				seconds = 1
+			}
+
+			header.Set("Retry-After", strconv.Itoa(seconds))
+			header.Set("X-RateLimit-Remaining", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+
+		handle(w, r)
+	}
+}
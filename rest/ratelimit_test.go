@@ -0,0 +1,84 @@
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenBucketLimiter_Allow(t *testing.T) {
+	limiter := newTokenBucketLimiter(RateLimitConf{Rate: 1, Burst: 1})
+
+	allowed, _ := limiter.Allow("client-1")
+	assert.True(t, allowed)
+
+	allowed, retryAfter := limiter.Allow("client-1")
+	assert.False(t, allowed)
+	assert.True(t, retryAfter > 0)
+}
+
+func TestTokenBucketLimiter_AllowPerKey(t *testing.T) {
+	limiter := newTokenBucketLimiter(RateLimitConf{Rate: 1, Burst: 1})
+
+	allowed, _ := limiter.Allow("client-1")
+	assert.True(t, allowed)
+
+	// a different key gets its own bucket, unaffected by client-1's.
+	allowed, _ = limiter.Allow("client-2")
+	assert.True(t, allowed)
+}
+
+func TestRateLimitHandler_AllowsThenRejects(t *testing.T) {
+	var calls int
+	handle := rateLimitHandler(RateLimitConf{Rate: 1, Burst: 1}, "ratelimit:test", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusOK)
+	})
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = "10.0.0.1:1234"
+
+	w := httptest.NewRecorder()
+	handle(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 1, calls)
+
+	w = httptest.NewRecorder()
+	handle(w, r)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, 1, calls)
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+	assert.Equal(t, "0", w.Header().Get("X-RateLimit-Remaining"))
+}
+
+func TestRateLimitHandler_SeparatesByKey(t *testing.T) {
+	handle := rateLimitHandler(RateLimitConf{Rate: 1, Burst: 1}, "ratelimit:test", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	r1 := httptest.NewRequest(http.MethodGet, "/", nil)
+	r1.RemoteAddr = "10.0.0.1:1234"
+	w1 := httptest.NewRecorder()
+	handle(w1, r1)
+	assert.Equal(t, http.StatusOK, w1.Code)
+
+	r2 := httptest.NewRequest(http.MethodGet, "/", nil)
+	r2.RemoteAddr = "10.0.0.2:1234"
+	w2 := httptest.NewRecorder()
+	handle(w2, r2)
+	assert.Equal(t, http.StatusOK, w2.Code)
+}
+
+func TestRemoteIPKey(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = "10.0.0.1:1234"
+	assert.Equal(t, "10.0.0.1", RemoteIPKey(r))
+}
+
+func TestHeaderKey(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("X-Client-Id", "abc")
+	assert.Equal(t, "abc", HeaderKey("X-Client-Id")(r))
+}
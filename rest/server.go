@@ -3,7 +3,9 @@ package rest
 import (
 	"crypto/tls"
 	"log"
+	"net"
 	"net/http"
+	"os"
 	"path"
 
 	"github.com/tal-tech/go-zero/core/logx"
@@ -59,6 +61,17 @@ func NewServer(c RestConf, opts ...RunOption) (*Server, error) {
 		opt(server)
 	}
 
+	// surfaces errors recorded by a RunOption (e.g. a broken WithTLSConfig,
+	// or WithUnixSocket failing to bind) here instead of waiting for
+	// Start, so they fail NewServer instead of panicking once the process
+	// is already running.
+	if server.ngin.listenerErr != nil {
+		// a WithListener/WithUnixSocket applied before the one that failed
+		// already bound its listener; close those rather than leak them.
+		server.ngin.closeListeners()
+		return nil, server.ngin.listenerErr
+	}
+
 	return server, nil
 }
 
@@ -102,25 +115,42 @@ func ToMiddleware(handler func(next http.Handler) http.Handler) Middleware {
 	}
 }
 
+// WithAuth returns a RouteOption that authenticates the route with backend
+// instead of an HS256 secret, e.g. NewJwksAuthBackend, NewIntrospectionAuthBackend,
+// NewBasicAuthBackend or NewAPIKeyAuthBackend for RS256/ES256 JWT, OAuth2/OIDC
+// token introspection, or basic/API-key auth respectively.
+func WithAuth(backend AuthBackend) RouteOption {
+	return func(r *featuredRoutes) {
+		r.jwt.enabled = true
+		r.jwt.backend = backend
+	}
+}
+
 // WithJwt returns a func to enable jwt authentication in given route.
+// secret/prevSecret are kept on featuredRoutes.jwt for backward
+// compatibility with anything built against the pre-AuthBackend field
+// layout; the engine builds the same HS256 backend from them when no
+// explicit backend (see WithAuth) is set.
 func WithJwt(secret string) RouteOption {
+	validateSecret(secret)
 	return func(r *featuredRoutes) {
-		validateSecret(secret)
 		r.jwt.enabled = true
 		r.jwt.secret = secret
+		r.jwt.backend = newJwtAuthBackend(secret, "")
 	}
 }
 
 // WithJwtTransition returns a func to enable jwt authentication as well as jwt secret transition.
 // Which means old and new jwt secrets work together for a period.
 func WithJwtTransition(secret, prevSecret string) RouteOption {
+	// why not validate prevSecret, because prevSecret is an already used one,
+	// even it not meet our requirement, we still need to allow the transition.
+	validateSecret(secret)
 	return func(r *featuredRoutes) {
-		// why not validate prevSecret, because prevSecret is an already used one,
-		// even it not meet our requirement, we still need to allow the transition.
-		validateSecret(secret)
 		r.jwt.enabled = true
 		r.jwt.secret = secret
 		r.jwt.prevSecret = prevSecret
+		r.jwt.backend = newJwtAuthBackend(secret, prevSecret)
 	}
 }
 
@@ -148,6 +178,78 @@ func WithMiddleware(middleware Middleware, rs ...Route) []Route {
 	return routes
 }
 
+// WithListener returns a RunOption that serves the Server additionally on the
+// given net.Listener, alongside the listener derived from RestConf. Multiple
+// listeners are served concurrently and share graceful shutdown.
+func WithListener(l net.Listener, opts ...ListenerOption) RunOption {
+	lc := listenerConf{ln: l}
+	for _, opt := range opts {
+		opt(&lc)
+	}
+
+	return func(server *Server) {
+		server.ngin.addListener(lc)
+	}
+}
+
+// WithUnixSocket returns a RunOption that serves the Server additionally on a
+// Unix domain socket created at path with the given file mode. It's commonly
+// paired with WithTrustedListener to expose an internal admin API that local
+// tooling can reach without credentials.
+func WithUnixSocket(path string, mode os.FileMode, opts ...ListenerOption) RunOption {
+	return func(server *Server) {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			server.ngin.addListenerErr(err)
+			return
+		}
+
+		ln, err := net.Listen("unix", path)
+		if err != nil {
+			server.ngin.addListenerErr(err)
+			return
+		}
+
+		if err := os.Chmod(path, mode); err != nil {
+			server.ngin.addListenerErr(err)
+			return
+		}
+
+		lc := listenerConf{ln: ln}
+		for _, opt := range opts {
+			opt(&lc)
+		}
+		server.ngin.addListener(lc)
+	}
+}
+
+// WithAutocert returns a RunOption that serves the Server with a TLS
+// certificate obtained and renewed automatically through ACME, e.g. Let's
+// Encrypt, instead of a certificate file configured on RestConf. It starts
+// an HTTP-01 challenge listener on conf.ChallengeAddr alongside the main
+// server.
+func WithAutocert(conf AutocertConf) RunOption {
+	return func(server *Server) {
+		server.ngin.setAutocertManager(conf.manager(), conf.ChallengeAddr)
+	}
+}
+
+// WithCORS returns a RouteOption that enables CORS for the route with conf,
+// taking precedence over a server-wide WithGlobalCORS.
+func WithCORS(conf CORSConf) RouteOption {
+	return func(r *featuredRoutes) {
+		r.cors.enabled = true
+		r.cors.CORSConf = conf
+	}
+}
+
+// WithGlobalCORS returns a RunOption that enables CORS with conf for every
+// route that doesn't set its own via WithCORS.
+func WithGlobalCORS(conf CORSConf) RunOption {
+	return func(server *Server) {
+		server.ngin.setGlobalCORS(conf)
+	}
+}
+
 // WithNotFoundHandler returns a RunOption with not found handler set to given handler.
 func WithNotFoundHandler(handler http.Handler) RunOption {
 	rt := router.NewRouter()
@@ -159,7 +261,14 @@ func WithNotFoundHandler(handler http.Handler) RunOption {
 func WithNotAllowedHandler(handler http.Handler) RunOption {
 	rt := router.NewRouter()
 	rt.SetNotAllowedHandler(handler)
-	return WithRouter(rt)
+
+	return func(server *Server) {
+		// recorded on the engine as well as rt, since corsNotAllowedHandler
+		// replaces rt's NotAllowedHandler once any route enables CORS, and
+		// needs handler to fall back to instead of a plain 405.
+		server.ngin.setNotAllowedHandler(handler)
+		WithRouter(rt)(server)
+	}
 }
 
 // WithPrefix adds group as a prefix to the route paths.
@@ -185,6 +294,28 @@ func WithPriority() RouteOption {
 	}
 }
 
+// WithRateLimit returns a RouteOption that limits the route to conf.Rate
+// requests per conf.Period (default one second), tracked per conf.KeyFunc
+// (default RemoteIPKey). A request over the limit gets a 429 with
+// Retry-After and X-RateLimit-* headers instead of reaching the handler.
+// Set conf.Redis to share quota across instances instead of limiting
+// in-process.
+func WithRateLimit(conf RateLimitConf) RouteOption {
+	return func(r *featuredRoutes) {
+		r.rateLimit.enabled = true
+		r.rateLimit.RateLimitConf = conf
+	}
+}
+
+// WithGlobalRateLimit returns a RunOption that applies conf to every
+// request the Server receives, before routing, protecting it from a flood
+// that a single route's WithRateLimit wouldn't catch.
+func WithGlobalRateLimit(conf RateLimitConf) RunOption {
+	return func(server *Server) {
+		server.ngin.setGlobalRateLimit(conf)
+	}
+}
+
 // WithRouter returns a RunOption that make server run with given router.
 func WithRouter(router httpx.Router) RunOption {
 	return func(server *Server) {
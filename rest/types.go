@@ -0,0 +1,65 @@
+package rest
+
+import "net/http"
+
+type (
+	// Middleware defines the method to wrap a http.HandlerFunc with extra behavior.
+	Middleware func(next http.HandlerFunc) http.HandlerFunc
+
+	// A Route is a http route.
+	Route struct {
+		Method  string
+		Path    string
+		Handler http.HandlerFunc
+	}
+
+	// RouteOption defines the method to customize a featured route.
+	RouteOption func(r *featuredRoutes)
+
+	jwtSetting struct {
+		enabled bool
+		// secret/prevSecret are kept for the HS256 fast path and for
+		// backward compatibility with code that only ever called
+		// WithJwt/WithJwtTransition; backend is consulted first when set.
+		secret     string
+		prevSecret string
+		backend    AuthBackend
+	}
+
+	signatureSetting struct {
+		SignatureConf
+		enabled bool
+	}
+
+	corsSetting struct {
+		CORSConf
+		enabled bool
+	}
+
+	rateLimitSetting struct {
+		RateLimitConf
+		enabled bool
+	}
+
+	featuredRoutes struct {
+		routes    []Route
+		priority  bool
+		jwt       jwtSetting
+		signature signatureSetting
+		cors      corsSetting
+		rateLimit rateLimitSetting
+	}
+)
+
+// A SignatureConf is the config for request signature verification.
+type SignatureConf struct {
+	Strict      bool             `json:",optional"`
+	Expiry      int64            `json:",optional"`
+	PrivateKeys []PrivateKeyConf `json:",optional"`
+}
+
+// A PrivateKeyConf is a fingerprint/private-key pair used to verify a signed request.
+type PrivateKeyConf struct {
+	Fingerprint string
+	KeyFile     string
+}